@@ -29,7 +29,9 @@ var (
 type Client struct {
 	apiKey       string
 	customerUUID string
+	baseURL      string
 	client       *http.Client
+	retryPolicy  *RetryPolicy
 }
 
 // Retrieves the configuration of the Ding API.
@@ -39,43 +41,82 @@ type Params struct {
 	APIKey string
 	// The UUID of the customer to use when making requests to the Ding API.
 	CustomerUUID string
+	// BaseURL overrides the Ding API base URL. Defaults to APIURL; mainly
+	// useful for pointing at a test server.
+	BaseURL string
+	// HTTPClient overrides the http.Client used to make requests,
+	// allowing callers to inject custom timeouts, transports (proxies,
+	// mTLS, OpenTelemetry-instrumented round-trippers), or test doubles.
+	// Defaults to &http.Client{}.
+	HTTPClient *http.Client
+	// RetryPolicy controls how transient failures (5xx, 429, transport
+	// errors) are retried. Defaults to DefaultRetryPolicy if nil.
+	RetryPolicy *RetryPolicy
 }
 
 func New(params *Params) *Client {
-	return &Client{apiKey: params.APIKey, customerUUID: params.CustomerUUID, client: &http.Client{}}
-}
+	retryPolicy := params.RetryPolicy
+	if retryPolicy == nil {
+		retryPolicy = DefaultRetryPolicy()
+	}
 
-// Authenticate sends a verification code to the given phone number.
-func (c *Client) Authenticate(ctx context.Context, phoneNumber string) (string, error) {
-	type request struct {
-		PhoneNumber  string `json:"phone_number"`
-		CustomerUUID string `json:"customer_uuid"`
+	baseURL := params.BaseURL
+	if baseURL == "" {
+		baseURL = APIURL
 	}
 
-	type response struct {
-		AuthenticationUUID string `json:"authentication_uuid"`
-		Status             string `json:"status"`
+	httpClient := params.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{}
 	}
 
-	body := request{PhoneNumber: phoneNumber, CustomerUUID: c.customerUUID}
+	return &Client{
+		apiKey:       params.APIKey,
+		customerUUID: params.CustomerUUID,
+		baseURL:      baseURL,
+		client:       httpClient,
+		retryPolicy:  retryPolicy,
+	}
+}
 
+// Post sends a JSON-encoded POST request to the given Ding API path,
+// retrying transient failures per the client's RetryPolicy. The caller is
+// responsible for closing the returned response body. It is exported so
+// that other packages in this module (e.g. pkg/ding/v2) can build on top
+// of the same transport, retry, and authentication behavior.
+func (c *Client) Post(ctx context.Context, path string, body any) (*http.Response, error) {
 	bodyBytes, err := json.Marshal(body)
 	if err != nil {
-		return "", fmt.Errorf("marshal request: %v", err)
+		return nil, fmt.Errorf("marshal request: %v", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/%s", APIURL, AuthPath), bytes.NewBuffer(bodyBytes))
-	if err != nil {
-		return "", fmt.Errorf("new request: %v", err)
+	return c.doWithRetry(ctx, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/%s", c.baseURL, path), bytes.NewBuffer(bodyBytes))
+		if err != nil {
+			return nil, fmt.Errorf("new request: %v", err)
+		}
+
+		req.Header.Set(APIKeyHeader, c.apiKey)
+		req.Header.Set("content-type", "application/json")
+
+		return req, nil
+	})
+}
+
+// Authenticate sends a verification code to the given phone number.
+func (c *Client) Authenticate(ctx context.Context, phoneNumber string) (string, error) {
+	type request struct {
+		PhoneNumber  string `json:"phone_number"`
+		CustomerUUID string `json:"customer_uuid"`
 	}
 
-	req.Header.Set(APIKeyHeader, c.apiKey)
-	req.Header.Set("content-type", "application/json")
+	body := request{PhoneNumber: phoneNumber, CustomerUUID: c.customerUUID}
 
-	res, err := c.client.Do(req)
+	res, err := c.Post(ctx, AuthPath, body)
 	if err != nil {
 		return "", fmt.Errorf("post request: %v", err)
 	}
+	defer res.Body.Close()
 
 	if res.StatusCode == http.StatusBadRequest {
 		return "", ErrInvalidInput
@@ -87,12 +128,12 @@ func (c *Client) Authenticate(ctx context.Context, phoneNumber string) (string,
 		return "", fmt.Errorf("unexpected status code: %d", res.StatusCode)
 	}
 
-	var resp response
-	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+	resp, err := DecodeAuthenticateResponse(res.Body)
+	if err != nil {
 		return "", fmt.Errorf("decode response: %v", err)
 	}
 
-	if resp.Status == "rate_limited" {
+	if resp.Status == StatusRateLimited {
 		return "", ErrRateLimited
 	}
 
@@ -107,33 +148,17 @@ func (c *Client) Check(ctx context.Context, authUUID uuid.UUID, phoneNumber stri
 		CheckCode          string `json:"check_code"`
 	}
 
-	type response struct {
-		AuthenticationUUID string `json:"authentication_uuid"`
-		Status             string `json:"status"`
-	}
-
 	body := request{
 		CustomerUUID:       c.customerUUID,
 		AuthenticationUUID: authUUID.String(),
 		CheckCode:          code,
 	}
-	bodyBytes, err := json.Marshal(body)
-	if err != nil {
-		return false, fmt.Errorf("marshal request: %v", err)
-	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/%s", APIURL, CheckPath), bytes.NewBuffer(bodyBytes))
-	if err != nil {
-		return false, fmt.Errorf("new request: %v", err)
-	}
-
-	req.Header.Set(APIKeyHeader, c.apiKey)
-	req.Header.Set("content-type", "application/json")
-
-	res, err := c.client.Do(req)
+	res, err := c.Post(ctx, CheckPath, body)
 	if err != nil {
 		return false, fmt.Errorf("post request: %v", err)
 	}
+	defer res.Body.Close()
 
 	if res.StatusCode == http.StatusBadRequest {
 		return false, ErrInvalidInput
@@ -142,14 +167,10 @@ func (c *Client) Check(ctx context.Context, authUUID uuid.UUID, phoneNumber stri
 		return false, ErrUnauthorized
 	}
 
-	var resp response
-	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+	resp, err := DecodeCheckResponse(res.Body)
+	if err != nil {
 		return false, fmt.Errorf("decode response: %v", err)
 	}
 
-	if resp.Status == "valid" {
-		return true, nil
-	}
-
-	return false, nil
+	return resp.Status == StatusValid, nil
 }
@@ -0,0 +1,163 @@
+package ding
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// noRetryPolicy disables retries so these tests exercise exactly one
+// request/response round trip per case; retry behavior itself is covered
+// by retry_test.go.
+func noRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{MaxRetries: 0}
+}
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	return New(&Params{
+		APIKey:       "test-key",
+		CustomerUUID: "test-customer",
+		BaseURL:      srv.URL,
+		HTTPClient:   srv.Client(),
+		RetryPolicy:  noRetryPolicy(),
+	})
+}
+
+func TestAuthenticate_Success(t *testing.T) {
+	wantUUID := uuid.NewString()
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"authentication_uuid": wantUUID,
+			"status":              "sent",
+		})
+	})
+
+	got, err := c.Authenticate(context.Background(), "+15555550100")
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if got != wantUUID {
+		t.Fatalf("authentication uuid = %q, want %q", got, wantUUID)
+	}
+}
+
+func TestAuthenticate_RateLimited(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"status": "rate_limited"})
+	})
+
+	_, err := c.Authenticate(context.Background(), "+15555550100")
+	if err != ErrRateLimited {
+		t.Fatalf("err = %v, want ErrRateLimited", err)
+	}
+}
+
+func TestAuthenticate_BadRequest(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	})
+
+	_, err := c.Authenticate(context.Background(), "+15555550100")
+	if err != ErrInvalidInput {
+		t.Fatalf("err = %v, want ErrInvalidInput", err)
+	}
+}
+
+func TestAuthenticate_Unauthorized(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	_, err := c.Authenticate(context.Background(), "+15555550100")
+	if err != ErrUnauthorized {
+		t.Fatalf("err = %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestAuthenticate_5xx(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	if _, err := c.Authenticate(context.Background(), "+15555550100"); err == nil {
+		t.Fatal("expected an error for a 5xx response, got nil")
+	}
+}
+
+func TestAuthenticate_429(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	if _, err := c.Authenticate(context.Background(), "+15555550100"); err == nil {
+		t.Fatal("expected an error for a 429 response, got nil")
+	}
+}
+
+func TestCheck_Valid(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"status": "valid"})
+	})
+
+	ok, err := c.Check(context.Background(), uuid.New(), "+15555550100", "123456")
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if !ok {
+		t.Fatal("Check: want true for a valid code")
+	}
+}
+
+func TestCheck_Invalid(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"status": "invalid"})
+	})
+
+	ok, err := c.Check(context.Background(), uuid.New(), "+15555550100", "000000")
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if ok {
+		t.Fatal("Check: want false for an invalid code")
+	}
+}
+
+func TestCheck_BadRequest(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	})
+
+	_, err := c.Check(context.Background(), uuid.New(), "+15555550100", "123456")
+	if err != ErrInvalidInput {
+		t.Fatalf("err = %v, want ErrInvalidInput", err)
+	}
+}
+
+func TestCheck_Unauthorized(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	_, err := c.Check(context.Background(), uuid.New(), "+15555550100", "123456")
+	if err != ErrUnauthorized {
+		t.Fatalf("err = %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestCheck_5xx(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	if _, err := c.Check(context.Background(), uuid.New(), "+15555550100", "123456"); err == nil {
+		t.Fatal("expected an error for a 5xx response, got nil")
+	}
+}
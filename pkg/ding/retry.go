@@ -0,0 +1,179 @@
+package ding
+
+import (
+	"context"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how the client retries transient failures against
+// the Ding API. The defaults follow the exponential-backoff-with-jitter
+// algorithm popularized by cenkalti/backoff: each attempt waits
+// min(MaxInterval, InitialInterval*Multiplier^n), randomized uniformly in
+// [interval*(1-RandomizationFactor), interval*(1+RandomizationFactor)].
+type RetryPolicy struct {
+	// MaxRetries is the maximum number of retries after the initial
+	// attempt. Zero disables retries entirely.
+	MaxRetries int
+	// InitialInterval is the backoff used for the first retry.
+	InitialInterval time.Duration
+	// MaxInterval caps the computed backoff, before jitter is applied.
+	MaxInterval time.Duration
+	// Multiplier is applied to the interval after every retry.
+	Multiplier float64
+	// RandomizationFactor controls the jitter applied around the
+	// computed interval, e.g. 0.5 jitters within +/-50%.
+	RandomizationFactor float64
+	// MaxElapsedTime bounds the total time spent retrying, measured from
+	// the first attempt. Zero means no bound beyond the context.
+	MaxElapsedTime time.Duration
+	// Retryable decides whether a given response/error combination
+	// should be retried. If nil, DefaultRetryable is used.
+	Retryable func(res *http.Response, err error) bool
+}
+
+// maxDrainBytes bounds how much of a retryable response body doWithRetry
+// reads before closing it, so an unexpectedly large or slow-streaming body
+// can't stall the retry loop.
+const maxDrainBytes = 64 << 10 // 64 KiB
+
+// DefaultRetryPolicy retries 5xx responses, 429s, and transport errors with
+// exponential backoff and jitter, for up to 30 seconds.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxRetries:          3,
+		InitialInterval:     500 * time.Millisecond,
+		MaxInterval:         10 * time.Second,
+		Multiplier:          2,
+		RandomizationFactor: 0.5,
+		MaxElapsedTime:      30 * time.Second,
+		Retryable:           DefaultRetryable,
+	}
+}
+
+// DefaultRetryable retries transport errors, 429, and any 5xx response. It
+// does not retry 400 or 401, which are surfaced as ErrInvalidInput and
+// ErrUnauthorized respectively.
+func DefaultRetryable(res *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	if res == nil {
+		return false
+	}
+	if res.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	return res.StatusCode >= 500
+}
+
+func (p *RetryPolicy) retryable() func(res *http.Response, err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable
+	}
+	return DefaultRetryable
+}
+
+// interval returns the backoff for the given retry attempt (0-indexed),
+// before jitter is applied.
+func (p *RetryPolicy) interval(attempt int) time.Duration {
+	interval := float64(p.InitialInterval) * math.Pow(p.Multiplier, float64(attempt))
+	if max := float64(p.MaxInterval); max > 0 && interval > max {
+		interval = max
+	}
+	return time.Duration(interval)
+}
+
+// jitter randomizes d uniformly in [d*(1-r), d*(1+r)].
+func jitter(d time.Duration, r float64) time.Duration {
+	if r <= 0 {
+		return d
+	}
+	delta := r * float64(d)
+	min := float64(d) - delta
+	max := float64(d) + delta
+	return time.Duration(min + rand.Float64()*(max-min))
+}
+
+// retryAfter parses the Retry-After header, which may be expressed either
+// as a number of seconds or an HTTP-date. It returns false if absent or
+// unparseable.
+func retryAfter(res *http.Response) (time.Duration, bool) {
+	if res == nil {
+		return 0, false
+	}
+	v := res.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// doWithRetry executes reqFn (which must build a fresh, unread request on
+// every call) and retries it according to policy. reqFn returning a
+// non-nil *http.Response with a retryable status causes its body to be
+// drained and closed before the next attempt.
+func (c *Client) doWithRetry(ctx context.Context, reqFn func() (*http.Request, error)) (*http.Response, error) {
+	policy := c.retryPolicy
+	if policy == nil {
+		policy = DefaultRetryPolicy()
+	}
+
+	start := time.Now()
+	retryable := policy.retryable()
+
+	for attempt := 0; ; attempt++ {
+		req, err := reqFn()
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := c.client.Do(req)
+		if !retryable(res, err) {
+			return res, err
+		}
+
+		if res != nil {
+			// Drain before closing so the Transport can reuse the
+			// connection for the next retry instead of opening a new one.
+			// Capped so a pathological, slow, or never-ending body can't
+			// block the retry loop; past the cap we give up on reuse and
+			// just close, which is still correct, only slower.
+			_, _ = io.CopyN(io.Discard, res.Body, maxDrainBytes)
+			res.Body.Close()
+		}
+
+		if attempt >= policy.MaxRetries {
+			return res, err
+		}
+
+		wait := jitter(policy.interval(attempt), policy.RandomizationFactor)
+		if ra, ok := retryAfter(res); ok {
+			wait = ra
+		}
+
+		if policy.MaxElapsedTime > 0 && time.Since(start)+wait > policy.MaxElapsedTime {
+			return res, err
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
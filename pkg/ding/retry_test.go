@@ -0,0 +1,377 @@
+package ding
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newRetryTestClient builds a Client pointed at srv with a RetryPolicy
+// tuned for fast tests (small intervals, no jitter so assertions on
+// timing are deterministic).
+func newRetryTestClient(srv *httptest.Server, policy *RetryPolicy) *Client {
+	return &Client{
+		apiKey:      "test-key",
+		baseURL:     srv.URL,
+		client:      srv.Client(),
+		retryPolicy: policy,
+	}
+}
+
+func fastPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxRetries:          5,
+		InitialInterval:     time.Millisecond,
+		MaxInterval:         5 * time.Millisecond,
+		Multiplier:          2,
+		RandomizationFactor: 0,
+		MaxElapsedTime:      time.Second,
+	}
+}
+
+func reqFn(ctx context.Context, c *Client) func() (*http.Request, error) {
+	return func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/", nil)
+	}
+}
+
+func TestDoWithRetry_RetriesOn5xxThenSucceeds(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) <= 2 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	c := newRetryTestClient(srv, fastPolicy())
+
+	res, err := c.doWithRetry(context.Background(), reqFn(context.Background(), c))
+	if err != nil {
+		t.Fatalf("doWithRetry: %v", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want %d", res.StatusCode, http.StatusOK)
+	}
+	if got := atomic.LoadInt32(&attempts); got != 3 {
+		t.Fatalf("attempts = %d, want 3", got)
+	}
+}
+
+func TestDoWithRetry_RetriesOn429(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	c := newRetryTestClient(srv, fastPolicy())
+
+	res, err := c.doWithRetry(context.Background(), reqFn(context.Background(), c))
+	if err != nil {
+		t.Fatalf("doWithRetry: %v", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want %d", res.StatusCode, http.StatusOK)
+	}
+	if got := atomic.LoadInt32(&attempts); got != 2 {
+		t.Fatalf("attempts = %d, want 2", got)
+	}
+}
+
+func TestDoWithRetry_DoesNotRetry400Or401(t *testing.T) {
+	for _, status := range []int{http.StatusBadRequest, http.StatusUnauthorized} {
+		var attempts int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&attempts, 1)
+			w.WriteHeader(status)
+		}))
+
+		c := newRetryTestClient(srv, fastPolicy())
+
+		res, err := c.doWithRetry(context.Background(), reqFn(context.Background(), c))
+		if err != nil {
+			t.Fatalf("doWithRetry: %v", err)
+		}
+		res.Body.Close()
+		srv.Close()
+
+		if res.StatusCode != status {
+			t.Fatalf("status = %d, want %d", res.StatusCode, status)
+		}
+		if got := atomic.LoadInt32(&attempts); got != 1 {
+			t.Fatalf("status %d: attempts = %d, want 1 (no retry)", status, got)
+		}
+	}
+}
+
+func TestDoWithRetry_GivesUpAfterMaxRetries(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	policy := fastPolicy()
+	policy.MaxRetries = 2
+
+	c := newRetryTestClient(srv, policy)
+
+	res, err := c.doWithRetry(context.Background(), reqFn(context.Background(), c))
+	if err != nil {
+		t.Fatalf("doWithRetry: %v", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d", res.StatusCode, http.StatusServiceUnavailable)
+	}
+	// One initial attempt plus MaxRetries retries.
+	if got := atomic.LoadInt32(&attempts); got != 3 {
+		t.Fatalf("attempts = %d, want 3", got)
+	}
+}
+
+func TestDoWithRetry_HonorsRetryAfterHeader(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	// InitialInterval is deliberately large; if Retry-After weren't
+	// honored, this call would take at least that long.
+	policy := &RetryPolicy{
+		MaxRetries:          1,
+		InitialInterval:     time.Minute,
+		MaxInterval:         time.Minute,
+		Multiplier:          2,
+		RandomizationFactor: 0,
+		MaxElapsedTime:      time.Minute,
+	}
+
+	c := newRetryTestClient(srv, policy)
+
+	start := time.Now()
+	res, err := c.doWithRetry(context.Background(), reqFn(context.Background(), c))
+	elapsed := time.Since(start)
+	if err != nil {
+		t.Fatalf("doWithRetry: %v", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want %d", res.StatusCode, http.StatusOK)
+	}
+	if elapsed > 5*time.Second {
+		t.Fatalf("took %s, Retry-After header was not honored", elapsed)
+	}
+}
+
+func TestDoWithRetry_StopsAtMaxElapsedTime(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	policy := &RetryPolicy{
+		MaxRetries:          1000,
+		InitialInterval:     20 * time.Millisecond,
+		MaxInterval:         20 * time.Millisecond,
+		Multiplier:          1,
+		RandomizationFactor: 0,
+		MaxElapsedTime:      30 * time.Millisecond,
+	}
+
+	c := newRetryTestClient(srv, policy)
+
+	res, err := c.doWithRetry(context.Background(), reqFn(context.Background(), c))
+	if err != nil {
+		t.Fatalf("doWithRetry: %v", err)
+	}
+	defer res.Body.Close()
+
+	// MaxElapsedTime should cut the run short of MaxRetries.
+	if got := atomic.LoadInt32(&attempts); got >= 1000 {
+		t.Fatalf("attempts = %d, expected MaxElapsedTime to stop retries early", got)
+	}
+}
+
+func TestDoWithRetry_StopsOnContextCancellation(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	policy := &RetryPolicy{
+		MaxRetries:          1000,
+		InitialInterval:     50 * time.Millisecond,
+		MaxInterval:         50 * time.Millisecond,
+		Multiplier:          1,
+		RandomizationFactor: 0,
+		MaxElapsedTime:      time.Minute,
+	}
+
+	c := newRetryTestClient(srv, policy)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := c.doWithRetry(ctx, reqFn(ctx, c))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+// TestDoWithRetry_DrainsBodyBeforeClosingForReuse is the regression test
+// for draining a retryable response's body before closing it: an
+// undrained body prevents the Transport from reusing the connection, so a
+// retry sequence that reused connections correctly opens exactly one.
+func TestDoWithRetry_DrainsBodyBeforeClosingForReuse(t *testing.T) {
+	var attempts int32
+	var newConns int32
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			// A non-empty body makes an undrained Close leave bytes
+			// unread on the connection, which is what stops the
+			// Transport from reusing it.
+			_, _ = w.Write([]byte("service unavailable, try again shortly"))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	srv.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&newConns, 1)
+		}
+	}
+	srv.Start()
+	defer srv.Close()
+
+	c := newRetryTestClient(srv, fastPolicy())
+
+	res, err := c.doWithRetry(context.Background(), reqFn(context.Background(), c))
+	if err != nil {
+		t.Fatalf("doWithRetry: %v", err)
+	}
+	defer res.Body.Close()
+
+	if got := atomic.LoadInt32(&newConns); got != 1 {
+		t.Fatalf("server accepted %d new connections across a %d-attempt retry sequence, want 1 (body not drained before close)", got, attempts)
+	}
+}
+
+// TestDoWithRetry_DrainCapStopsHangOnOversizedBody guards maxDrainBytes:
+// a retryable response whose body never ends must not block the retry
+// loop forever, since draining only reads up to the cap before closing.
+func TestDoWithRetry_DrainCapStopsHangOnOversizedBody(t *testing.T) {
+	var attempts int32
+	block := make(chan struct{})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&attempts, 1)
+		if n == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write(make([]byte, maxDrainBytes+1024))
+			if f, ok := w.(http.Flusher); ok {
+				f.Flush()
+			}
+			<-block // never terminate this response on its own
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	// close(block) must unblock the stuck handler before srv.Close() waits
+	// for it, so run it first (defers are LIFO).
+	defer srv.Close()
+	defer close(block)
+
+	c := newRetryTestClient(srv, fastPolicy())
+
+	done := make(chan struct{})
+	var res *http.Response
+	var err error
+	go func() {
+		res, err = c.doWithRetry(context.Background(), reqFn(context.Background(), c))
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("doWithRetry did not return; an oversized body blocked the drain past maxDrainBytes")
+	}
+
+	if err != nil {
+		t.Fatalf("doWithRetry: %v", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want %d", res.StatusCode, http.StatusOK)
+	}
+}
+
+func TestDoWithRetry_RetriesOnTransportError(t *testing.T) {
+	var calls int32
+	c := &Client{
+		apiKey:      "test-key",
+		baseURL:     "http://127.0.0.1:0",
+		client:      &http.Client{Transport: flakyTransport{calls: &calls, failFor: 2}},
+		retryPolicy: fastPolicy(),
+	}
+
+	res, err := c.doWithRetry(context.Background(), func() (*http.Request, error) {
+		return http.NewRequestWithContext(context.Background(), http.MethodPost, "http://127.0.0.1:0/", nil)
+	})
+	if err != nil {
+		t.Fatalf("doWithRetry: %v", err)
+	}
+	defer res.Body.Close()
+
+	if got := atomic.LoadInt32(&calls); got != 3 {
+		t.Fatalf("calls = %d, want 3", got)
+	}
+}
+
+// flakyTransport fails the first failFor calls with a transport error, then
+// succeeds with a 200 response without hitting the network.
+type flakyTransport struct {
+	calls   *int32
+	failFor int32
+}
+
+func (f flakyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if atomic.AddInt32(f.calls, 1) <= f.failFor {
+		return nil, errors.New("simulated transport error")
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       http.NoBody,
+		Header:     make(http.Header),
+	}, nil
+}
@@ -0,0 +1,151 @@
+package v2
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ding-live/firebase/pkg/ding"
+	"github.com/google/uuid"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	return New(&ding.Params{
+		APIKey:       "test-key",
+		CustomerUUID: "test-customer",
+		BaseURL:      srv.URL,
+		HTTPClient:   srv.Client(),
+		RetryPolicy:  &ding.RetryPolicy{MaxRetries: 0},
+	})
+}
+
+func TestAuthenticate_Success(t *testing.T) {
+	wantUUID := uuid.NewString()
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"authentication_uuid": wantUUID,
+			"status":              "sent",
+			"remaining_attempts":  3,
+		})
+	})
+
+	got, err := c.Authenticate(context.Background(), "+15555550100")
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if got.UUID.String() != wantUUID {
+		t.Fatalf("UUID = %q, want %q", got.UUID, wantUUID)
+	}
+	if got.Status != AuthenticationStatusSent {
+		t.Fatalf("Status = %q, want %q", got.Status, AuthenticationStatusSent)
+	}
+	if got.RemainingAttempts != 3 {
+		t.Fatalf("RemainingAttempts = %d, want 3", got.RemainingAttempts)
+	}
+}
+
+// TestAuthenticate_RateLimitedWithoutUUID is the regression test for
+// 6708537: a rate-limited response carries no authentication_uuid, and that
+// must not be treated as a parse error.
+func TestAuthenticate_RateLimitedWithoutUUID(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"authentication_uuid": "",
+			"status":              "rate_limited",
+		})
+	})
+
+	got, err := c.Authenticate(context.Background(), "+15555550100")
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if got.Status != AuthenticationStatusRateLimited {
+		t.Fatalf("Status = %q, want %q", got.Status, AuthenticationStatusRateLimited)
+	}
+	if got.UUID != uuid.Nil {
+		t.Fatalf("UUID = %q, want the zero UUID", got.UUID)
+	}
+}
+
+func TestAuthenticate_SentWithoutUUIDIsAnError(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"authentication_uuid": "",
+			"status":              "sent",
+		})
+	})
+
+	if _, err := c.Authenticate(context.Background(), "+15555550100"); err == nil {
+		t.Fatal("expected an error for a sent status with no authentication uuid")
+	}
+}
+
+func TestAuthenticate_BadRequest(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"code": "invalid_phone_number", "message": "bad phone number"})
+	})
+
+	_, err := c.Authenticate(context.Background(), "+1")
+	apiErr, ok := err.(*APIError)
+	if !ok {
+		t.Fatalf("err = %v (%T), want *APIError", err, err)
+	}
+	if apiErr.HTTPStatus != http.StatusBadRequest || apiErr.Code != "invalid_phone_number" {
+		t.Fatalf("apiErr = %+v, want status 400 code invalid_phone_number", apiErr)
+	}
+}
+
+func TestCheck_Valid(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"status": "valid"})
+	})
+
+	authUUID := uuid.New()
+	got, err := c.Check(context.Background(), authUUID, "+15555550100", "123456")
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if got.Status != CheckStatusValid {
+		t.Fatalf("Status = %q, want %q", got.Status, CheckStatusValid)
+	}
+	if got.AuthenticationUUID != authUUID {
+		t.Fatalf("AuthenticationUUID = %q, want %q", got.AuthenticationUUID, authUUID)
+	}
+}
+
+func TestCheck_UnrecognizedStatusIsUnknown(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"status": "some_future_status"})
+	})
+
+	got, err := c.Check(context.Background(), uuid.New(), "+15555550100", "123456")
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if got.Status != CheckStatusUnknown {
+		t.Fatalf("Status = %q, want %q", got.Status, CheckStatusUnknown)
+	}
+}
+
+func TestCheck_Unauthorized(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req-123")
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	_, err := c.Check(context.Background(), uuid.New(), "+15555550100", "123456")
+	apiErr, ok := err.(*APIError)
+	if !ok {
+		t.Fatalf("err = %v (%T), want *APIError", err, err)
+	}
+	if apiErr.HTTPStatus != http.StatusUnauthorized || apiErr.RequestID != "req-123" {
+		t.Fatalf("apiErr = %+v, want status 401 request req-123", apiErr)
+	}
+}
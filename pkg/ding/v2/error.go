@@ -0,0 +1,51 @@
+package v2
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// APIError is a structured error returned by the Ding API, so that callers
+// can use errors.As instead of comparing against sentinel errors.
+type APIError struct {
+	HTTPStatus int
+	Code       string
+	Message    string
+	RequestID  string
+}
+
+func (e *APIError) Error() string {
+	if e.RequestID != "" {
+		return fmt.Sprintf("ding: %s (status %d, code %s, request %s)", e.Message, e.HTTPStatus, e.Code, e.RequestID)
+	}
+	return fmt.Sprintf("ding: %s (status %d, code %s)", e.Message, e.HTTPStatus, e.Code)
+}
+
+// newAPIError returns an *APIError describing res if it represents a
+// failure, or nil if res is a successful (2xx) response. The response body
+// is consumed when present; callers must not read it afterward.
+func newAPIError(res *http.Response) *APIError {
+	if res.StatusCode < 400 {
+		return nil
+	}
+
+	type errBody struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+
+	var body errBody
+	_ = json.NewDecoder(res.Body).Decode(&body)
+
+	if body.Message == "" {
+		body.Message = http.StatusText(res.StatusCode)
+	}
+
+	return &APIError{
+		HTTPStatus: res.StatusCode,
+		Code:       body.Code,
+		Message:    body.Message,
+		RequestID:  res.Header.Get("X-Request-Id"),
+	}
+}
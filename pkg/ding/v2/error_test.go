@@ -0,0 +1,53 @@
+package v2
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewAPIError_Success(t *testing.T) {
+	res := httptest.NewRecorder().Result()
+	res.StatusCode = http.StatusOK
+
+	if err := newAPIError(res); err != nil {
+		t.Fatalf("newAPIError for a 2xx response = %v, want nil", err)
+	}
+}
+
+func TestNewAPIError_ParsesBodyAndHeader(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rec.Header().Set("X-Request-Id", "req-456")
+	rec.WriteHeader(http.StatusBadRequest)
+	rec.Body.WriteString(`{"code":"invalid_phone_number","message":"bad phone number"}`)
+
+	err := newAPIError(rec.Result())
+	if err == nil {
+		t.Fatal("newAPIError for a 4xx response = nil, want an error")
+	}
+	if err.HTTPStatus != http.StatusBadRequest {
+		t.Errorf("HTTPStatus = %d, want %d", err.HTTPStatus, http.StatusBadRequest)
+	}
+	if err.Code != "invalid_phone_number" {
+		t.Errorf("Code = %q, want %q", err.Code, "invalid_phone_number")
+	}
+	if err.Message != "bad phone number" {
+		t.Errorf("Message = %q, want %q", err.Message, "bad phone number")
+	}
+	if err.RequestID != "req-456" {
+		t.Errorf("RequestID = %q, want %q", err.RequestID, "req-456")
+	}
+}
+
+func TestNewAPIError_FallsBackToStatusTextWithoutBody(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rec.WriteHeader(http.StatusInternalServerError)
+
+	err := newAPIError(rec.Result())
+	if err == nil {
+		t.Fatal("newAPIError for a 5xx response = nil, want an error")
+	}
+	if err.Message != http.StatusText(http.StatusInternalServerError) {
+		t.Errorf("Message = %q, want %q", err.Message, http.StatusText(http.StatusInternalServerError))
+	}
+}
@@ -0,0 +1,71 @@
+package v2
+
+import (
+	"time"
+
+	"github.com/ding-live/firebase/pkg/ding"
+	"github.com/google/uuid"
+)
+
+// AuthenticationStatus describes the lifecycle state of an authentication
+// request.
+type AuthenticationStatus string
+
+const (
+	AuthenticationStatusSent        AuthenticationStatus = ding.StatusSent
+	AuthenticationStatusRateLimited AuthenticationStatus = ding.StatusRateLimited
+	// AuthenticationStatusUnknown is returned when the API responds with a
+	// status value this client version does not recognize, so that
+	// callers can fail safe instead of silently matching the zero value.
+	AuthenticationStatusUnknown AuthenticationStatus = "unknown"
+)
+
+func parseAuthenticationStatus(raw string) AuthenticationStatus {
+	switch s := AuthenticationStatus(raw); s {
+	case AuthenticationStatusSent, AuthenticationStatusRateLimited:
+		return s
+	default:
+		return AuthenticationStatusUnknown
+	}
+}
+
+// AuthenticationResult is the outcome of a successful call to
+// Client.Authenticate.
+type AuthenticationResult struct {
+	UUID              uuid.UUID
+	Status            AuthenticationStatus
+	CreatedAt         time.Time
+	ExpiresAt         time.Time
+	RemainingAttempts int
+}
+
+// CheckStatus describes the outcome of verifying a code against an
+// authentication request.
+type CheckStatus string
+
+const (
+	CheckStatusValid               CheckStatus = ding.StatusValid
+	CheckStatusInvalid             CheckStatus = ding.StatusInvalid
+	CheckStatusExpired             CheckStatus = ding.StatusExpired
+	CheckStatusAlreadyChecked      CheckStatus = ding.StatusAlreadyChecked
+	CheckStatusRateLimited         CheckStatus = ding.StatusRateLimited
+	CheckStatusMaxAttemptsExceeded CheckStatus = ding.StatusMaxAttemptsExceeded
+	// CheckStatusUnknown is returned when the API responds with a status
+	// value this client version does not recognize.
+	CheckStatusUnknown CheckStatus = "unknown"
+)
+
+func parseCheckStatus(raw string) CheckStatus {
+	switch s := CheckStatus(raw); s {
+	case CheckStatusValid, CheckStatusInvalid, CheckStatusExpired, CheckStatusAlreadyChecked, CheckStatusRateLimited, CheckStatusMaxAttemptsExceeded:
+		return s
+	default:
+		return CheckStatusUnknown
+	}
+}
+
+// CheckResult is the outcome of a successful call to Client.Check.
+type CheckResult struct {
+	AuthenticationUUID uuid.UUID
+	Status             CheckStatus
+}
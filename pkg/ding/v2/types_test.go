@@ -0,0 +1,37 @@
+package v2
+
+import "testing"
+
+func TestParseAuthenticationStatus(t *testing.T) {
+	cases := map[string]AuthenticationStatus{
+		"sent":                       AuthenticationStatusSent,
+		"rate_limited":               AuthenticationStatusRateLimited,
+		"never_heard_of_this_status": AuthenticationStatusUnknown,
+		"":                           AuthenticationStatusUnknown,
+	}
+
+	for raw, want := range cases {
+		if got := parseAuthenticationStatus(raw); got != want {
+			t.Errorf("parseAuthenticationStatus(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
+
+func TestParseCheckStatus(t *testing.T) {
+	cases := map[string]CheckStatus{
+		"valid":                      CheckStatusValid,
+		"invalid":                    CheckStatusInvalid,
+		"expired":                    CheckStatusExpired,
+		"already_checked":            CheckStatusAlreadyChecked,
+		"rate_limited":               CheckStatusRateLimited,
+		"max_attempts_exceeded":      CheckStatusMaxAttemptsExceeded,
+		"never_heard_of_this_status": CheckStatusUnknown,
+		"":                           CheckStatusUnknown,
+	}
+
+	for raw, want := range cases {
+		if got := parseCheckStatus(raw); got != want {
+			t.Errorf("parseCheckStatus(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
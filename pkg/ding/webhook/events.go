@@ -0,0 +1,56 @@
+package webhook
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EventType identifies the kind of webhook delivery Ding sent.
+type EventType string
+
+const (
+	EventTypeAuthenticationDelivered EventType = "authentication.delivered"
+	EventTypeAuthenticationFailed    EventType = "authentication.failed"
+	EventTypeCheckSucceeded          EventType = "check.succeeded"
+	EventTypeCheckFailed             EventType = "check.failed"
+)
+
+// Event is a single webhook delivery from Ding. Exactly one of the typed
+// fields is populated, matching Type.
+type Event struct {
+	ID        string    `json:"id"`
+	Type      EventType `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+
+	AuthenticationDelivered *AuthenticationDelivered `json:"authentication_delivered,omitempty"`
+	AuthenticationFailed    *AuthenticationFailed    `json:"authentication_failed,omitempty"`
+	CheckSucceeded          *CheckSucceeded          `json:"check_succeeded,omitempty"`
+	CheckFailed             *CheckFailed             `json:"check_failed,omitempty"`
+}
+
+// AuthenticationDelivered reports that a verification code was delivered to
+// the user's phone.
+type AuthenticationDelivered struct {
+	AuthenticationUUID uuid.UUID `json:"authentication_uuid"`
+	PhoneNumber        string    `json:"phone_number"`
+}
+
+// AuthenticationFailed reports that Ding could not deliver a verification
+// code.
+type AuthenticationFailed struct {
+	AuthenticationUUID uuid.UUID `json:"authentication_uuid"`
+	PhoneNumber        string    `json:"phone_number"`
+	Reason             string    `json:"reason"`
+}
+
+// CheckSucceeded reports that a verification code was checked successfully.
+type CheckSucceeded struct {
+	AuthenticationUUID uuid.UUID `json:"authentication_uuid"`
+}
+
+// CheckFailed reports that a verification code check failed.
+type CheckFailed struct {
+	AuthenticationUUID uuid.UUID `json:"authentication_uuid"`
+	Reason             string    `json:"reason"`
+}
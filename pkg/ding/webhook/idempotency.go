@@ -0,0 +1,64 @@
+package webhook
+
+import (
+	"container/list"
+	"sync"
+)
+
+// idempotencyCache is a fixed-size, in-memory LRU of event IDs that have
+// already been dispatched, so that duplicate webhook deliveries (which Ding,
+// like most providers, may send more than once) are dropped.
+type idempotencyCache struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List
+	items    map[string]*list.Element
+}
+
+func newIdempotencyCache(capacity int) *idempotencyCache {
+	if capacity <= 0 {
+		capacity = 1024
+	}
+	return &idempotencyCache{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element, capacity),
+	}
+}
+
+// seen reports whether id has already been recorded. It does not record id
+// itself, so that a caller can defer recording until the event has actually
+// been dispatched successfully; see add.
+func (c *idempotencyCache) seen(id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[id]
+	if !ok {
+		return false
+	}
+
+	c.ll.MoveToFront(el)
+	return true
+}
+
+// add records id as seen, evicting the least-recently-used entry if the
+// cache is over capacity.
+func (c *idempotencyCache) add(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[id]; ok {
+		c.ll.MoveToFront(el)
+		return
+	}
+
+	c.items[id] = c.ll.PushFront(id)
+	if c.ll.Len() > c.capacity {
+		oldest := c.ll.Back()
+		if oldest != nil {
+			c.ll.Remove(oldest)
+			delete(c.items, oldest.Value.(string))
+		}
+	}
+}
@@ -0,0 +1,164 @@
+// Package webhook receives and verifies Ding webhook deliveries
+// (authentication/check lifecycle events) and dispatches them to a
+// user-supplied callback.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// SignatureHeader carries base64(hmac_sha256(secret, timestamp+"\n"+body)).
+	SignatureHeader = "X-Ding-Signature"
+	// TimestampHeader carries the unix timestamp the signature was computed
+	// over, used to reject replayed deliveries.
+	TimestampHeader = "X-Ding-Timestamp"
+
+	// MaxClockSkew is how far TimestampHeader may drift from the receiver's
+	// clock, in either direction, before a delivery is rejected as a
+	// possible replay.
+	MaxClockSkew = 5 * time.Minute
+
+	// idempotencyCacheSize bounds the number of recently-seen event IDs
+	// kept in memory to drop duplicate deliveries.
+	idempotencyCacheSize = 4096
+)
+
+var (
+	ErrMissingSignature = errors.New("webhook: missing signature header")
+	ErrInvalidSignature = errors.New("webhook: signature mismatch")
+	ErrStaleTimestamp   = errors.New("webhook: timestamp outside allowed window")
+)
+
+// OnEvent is called once per newly-seen, signature-verified webhook event.
+type OnEvent func(context.Context, Event) error
+
+// NewHandler returns an http.Handler that verifies inbound Ding webhook
+// deliveries against secret and invokes onEvent for each one. Requests with
+// a missing/invalid signature, a stale timestamp, or a malformed body are
+// rejected with 4xx without calling onEvent. Duplicate deliveries (by event
+// ID) are accepted but not redelivered to onEvent.
+func NewHandler(secret string, onEvent OnEvent) http.Handler {
+	cache := newIdempotencyCache(idempotencyCacheSize)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "read body", http.StatusBadRequest)
+			return
+		}
+
+		if err := verify(r.Header, secret, body, time.Now()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		var event Event
+		if err := json.Unmarshal(body, &event); err != nil {
+			http.Error(w, fmt.Sprintf("decode event: %s", err), http.StatusBadRequest)
+			return
+		}
+
+		if cache.seen(event.ID) {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		if err := onEvent(r.Context(), event); err != nil {
+			http.Error(w, fmt.Sprintf("handle event: %s", err), http.StatusInternalServerError)
+			return
+		}
+		cache.add(event.ID)
+
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+// Middleware wraps next, verifying and dispatching Ding webhook deliveries
+// the same way NewHandler does, for callers who want to mount the receiver
+// under an existing mux alongside other routes. next is invoked after
+// onEvent succeeds, with the request body restored for downstream handlers.
+func Middleware(secret string, onEvent OnEvent) func(http.Handler) http.Handler {
+	cache := newIdempotencyCache(idempotencyCacheSize)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, "read body", http.StatusBadRequest)
+				return
+			}
+			r.Body.Close()
+
+			if err := verify(r.Header, secret, body, time.Now()); err != nil {
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+
+			var event Event
+			if err := json.Unmarshal(body, &event); err != nil {
+				http.Error(w, fmt.Sprintf("decode event: %s", err), http.StatusBadRequest)
+				return
+			}
+
+			if !cache.seen(event.ID) {
+				if err := onEvent(r.Context(), event); err != nil {
+					http.Error(w, fmt.Sprintf("handle event: %s", err), http.StatusInternalServerError)
+					return
+				}
+				cache.add(event.ID)
+			}
+
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// verify checks the HMAC signature and replay window for a webhook
+// delivery.
+func verify(header http.Header, secret string, body []byte, now time.Time) error {
+	sig := header.Get(SignatureHeader)
+	if sig == "" {
+		return ErrMissingSignature
+	}
+
+	ts := header.Get(TimestampHeader)
+	tsSeconds, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return ErrStaleTimestamp
+	}
+
+	sentAt := time.Unix(tsSeconds, 0)
+	if now.Sub(sentAt).Abs() > MaxClockSkew {
+		return ErrStaleTimestamp
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("\n"))
+	mac.Write(body)
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	if !hmac.Equal([]byte(expected), []byte(sig)) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
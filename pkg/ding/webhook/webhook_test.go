@@ -0,0 +1,195 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const testSecret = "test-secret"
+
+func sign(secret, ts string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("\n"))
+	mac.Write(body)
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func newRequest(t *testing.T, body []byte, ts string, sig string) *http.Request {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	if ts != "" {
+		req.Header.Set(TimestampHeader, ts)
+	}
+	if sig != "" {
+		req.Header.Set(SignatureHeader, sig)
+	}
+	return req
+}
+
+func TestNewHandler_ValidSignatureAccepted(t *testing.T) {
+	body := []byte(`{"id":"evt_1","type":"check.succeeded"}`)
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+
+	var gotID string
+	handler := NewHandler(testSecret, func(ctx context.Context, ev Event) error {
+		gotID = ev.ID
+		return nil
+	})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newRequest(t, body, ts, sign(testSecret, ts, body)))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	if gotID != "evt_1" {
+		t.Fatalf("onEvent called with ID %q, want evt_1", gotID)
+	}
+}
+
+func TestNewHandler_MissingSignatureRejected(t *testing.T) {
+	body := []byte(`{"id":"evt_1","type":"check.succeeded"}`)
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+
+	called := false
+	handler := NewHandler(testSecret, func(ctx context.Context, ev Event) error {
+		called = true
+		return nil
+	})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newRequest(t, body, ts, ""))
+
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401", w.Code)
+	}
+	if called {
+		t.Fatal("onEvent should not be called for a missing signature")
+	}
+}
+
+func TestNewHandler_WrongSignatureRejected(t *testing.T) {
+	body := []byte(`{"id":"evt_1","type":"check.succeeded"}`)
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+
+	called := false
+	handler := NewHandler(testSecret, func(ctx context.Context, ev Event) error {
+		called = true
+		return nil
+	})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newRequest(t, body, ts, sign("wrong-secret", ts, body)))
+
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401", w.Code)
+	}
+	if called {
+		t.Fatal("onEvent should not be called for a wrong signature")
+	}
+}
+
+func TestNewHandler_StaleTimestampRejected(t *testing.T) {
+	body := []byte(`{"id":"evt_1","type":"check.succeeded"}`)
+	ts := strconv.FormatInt(time.Now().Add(-2*MaxClockSkew).Unix(), 10)
+
+	called := false
+	handler := NewHandler(testSecret, func(ctx context.Context, ev Event) error {
+		called = true
+		return nil
+	})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newRequest(t, body, ts, sign(testSecret, ts, body)))
+
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401", w.Code)
+	}
+	if called {
+		t.Fatal("onEvent should not be called for a stale timestamp")
+	}
+}
+
+func TestNewHandler_MalformedTimestampRejected(t *testing.T) {
+	body := []byte(`{"id":"evt_1","type":"check.succeeded"}`)
+
+	handler := NewHandler(testSecret, func(ctx context.Context, ev Event) error {
+		t.Fatal("onEvent should not be called for a malformed timestamp")
+		return nil
+	})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newRequest(t, body, "not-a-number", sign(testSecret, "not-a-number", body)))
+
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401", w.Code)
+	}
+}
+
+func TestNewHandler_DuplicateEventNotRedispatched(t *testing.T) {
+	body := []byte(`{"id":"evt_1","type":"check.succeeded"}`)
+
+	calls := 0
+	handler := NewHandler(testSecret, func(ctx context.Context, ev Event) error {
+		calls++
+		return nil
+	})
+
+	for i := 0; i < 2; i++ {
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, newRequest(t, body, ts, sign(testSecret, ts, body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("delivery %d: status = %d, want 200", i, w.Code)
+		}
+	}
+
+	if calls != 1 {
+		t.Fatalf("onEvent called %d times, want 1", calls)
+	}
+}
+
+// TestNewHandler_FailedOnEventNotMarkedSeen is the regression test for
+// c82342e: an onEvent failure must not mark the event as seen, so a
+// provider retry of the same event ID reaches onEvent again instead of
+// being silently dropped.
+func TestNewHandler_FailedOnEventNotMarkedSeen(t *testing.T) {
+	body := []byte(`{"id":"evt_1","type":"check.succeeded"}`)
+
+	calls := 0
+	handler := NewHandler(testSecret, func(ctx context.Context, ev Event) error {
+		calls++
+		if calls == 1 {
+			return errors.New("transient downstream failure")
+		}
+		return nil
+	})
+
+	ts1 := strconv.FormatInt(time.Now().Unix(), 10)
+	w1 := httptest.NewRecorder()
+	handler.ServeHTTP(w1, newRequest(t, body, ts1, sign(testSecret, ts1, body)))
+	if w1.Code != http.StatusInternalServerError {
+		t.Fatalf("delivery 1: status = %d, want 500", w1.Code)
+	}
+
+	ts2 := strconv.FormatInt(time.Now().Unix(), 10)
+	w2 := httptest.NewRecorder()
+	handler.ServeHTTP(w2, newRequest(t, body, ts2, sign(testSecret, ts2, body)))
+	if w2.Code != http.StatusOK {
+		t.Fatalf("delivery 2 (retry): status = %d, want 200", w2.Code)
+	}
+
+	if calls != 2 {
+		t.Fatalf("onEvent called %d times, want 2 (retry must reach onEvent again)", calls)
+	}
+}
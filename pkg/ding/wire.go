@@ -0,0 +1,57 @@
+package ding
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// Raw status strings used in Authenticate and Check responses. They are
+// exported so that pkg/ding/v2 can map them to its typed status enums
+// without redefining the literals.
+const (
+	StatusSent                = "sent"
+	StatusRateLimited         = "rate_limited"
+	StatusValid               = "valid"
+	StatusInvalid             = "invalid"
+	StatusExpired             = "expired"
+	StatusAlreadyChecked      = "already_checked"
+	StatusMaxAttemptsExceeded = "max_attempts_exceeded"
+)
+
+// AuthenticateResponse is the decoded wire shape of an Authenticate
+// response. v1's Authenticate only surfaces AuthenticationUUID and Status;
+// the remaining fields are surfaced by pkg/ding/v2.
+type AuthenticateResponse struct {
+	AuthenticationUUID string    `json:"authentication_uuid"`
+	Status             string    `json:"status"`
+	CreatedAt          time.Time `json:"created_at"`
+	ExpiresAt          time.Time `json:"expires_at"`
+	RemainingAttempts  int       `json:"remaining_attempts"`
+}
+
+// DecodeAuthenticateResponse decodes an Authenticate response body. It is
+// exported so pkg/ding/v2 shares this decoding instead of redefining it.
+func DecodeAuthenticateResponse(body io.Reader) (*AuthenticateResponse, error) {
+	var resp AuthenticateResponse
+	if err := json.NewDecoder(body).Decode(&resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// CheckResponse is the decoded wire shape of a Check response.
+type CheckResponse struct {
+	AuthenticationUUID string `json:"authentication_uuid"`
+	Status             string `json:"status"`
+}
+
+// DecodeCheckResponse decodes a Check response body. It is exported so
+// pkg/ding/v2 shares this decoding instead of redefining it.
+func DecodeCheckResponse(body io.Reader) (*CheckResponse, error) {
+	var resp CheckResponse
+	if err := json.NewDecoder(body).Decode(&resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
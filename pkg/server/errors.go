@@ -0,0 +1,22 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/ding-live/firebase/pkg/ding"
+)
+
+// dingErrToHTTP maps a pkg/ding sentinel error to the HTTP status that
+// should be returned to the caller.
+func dingErrToHTTP(err error) int {
+	switch err {
+	case ding.ErrUnauthorized:
+		return http.StatusUnauthorized
+	case ding.ErrInvalidInput:
+		return http.StatusBadRequest
+	case ding.ErrRateLimited:
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
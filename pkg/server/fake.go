@@ -0,0 +1,117 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"unsafe"
+
+	"firebase.google.com/go/auth"
+	"github.com/google/uuid"
+)
+
+// FakeDingVerifier is an in-memory DingVerifier for tests.
+type FakeDingVerifier struct {
+	// AuthenticateFunc overrides Authenticate's behavior. If nil,
+	// Authenticate generates a random UUID and succeeds.
+	AuthenticateFunc func(ctx context.Context, phoneNumber string) (string, error)
+	// CheckFunc overrides Check's behavior. If nil, Check succeeds only
+	// when code equals ValidCode.
+	CheckFunc func(ctx context.Context, authUUID uuid.UUID, phoneNumber string, code string) (bool, error)
+	// ValidCode is the code Check accepts when CheckFunc is nil.
+	ValidCode string
+}
+
+func (f *FakeDingVerifier) Authenticate(ctx context.Context, phoneNumber string) (string, error) {
+	if f.AuthenticateFunc != nil {
+		return f.AuthenticateFunc(ctx, phoneNumber)
+	}
+	return uuid.NewString(), nil
+}
+
+func (f *FakeDingVerifier) Check(ctx context.Context, authUUID uuid.UUID, phoneNumber string, code string) (bool, error) {
+	if f.CheckFunc != nil {
+		return f.CheckFunc(ctx, authUUID, phoneNumber, code)
+	}
+	return code == f.ValidCode, nil
+}
+
+// ErrUserNotFound is returned by FakeFirebaseAuth.GetUserByPhoneNumber when
+// no user has been created for the given phone number yet, mirroring the
+// behavior of the real auth.Client.
+var ErrUserNotFound = errors.New("user not found")
+
+// FakeFirebaseAuth is an in-memory FirebaseAuth for tests.
+type FakeFirebaseAuth struct {
+	// CustomTokenFunc overrides CustomToken's behavior. If nil,
+	// CustomToken returns a deterministic "token-<uid>" string.
+	CustomTokenFunc func(ctx context.Context, uid string) (string, error)
+
+	mu    sync.Mutex
+	users map[string]*auth.UserRecord // keyed by phone number
+	seq   int
+}
+
+func NewFakeFirebaseAuth() *FakeFirebaseAuth {
+	return &FakeFirebaseAuth{users: make(map[string]*auth.UserRecord)}
+}
+
+func (f *FakeFirebaseAuth) GetUserByPhoneNumber(ctx context.Context, phoneNumber string) (*auth.UserRecord, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	user, ok := f.users[phoneNumber]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (f *FakeFirebaseAuth) CreateUser(ctx context.Context, user *auth.UserToCreate) (*auth.UserRecord, error) {
+	phoneNumber := phoneNumberFromUserToCreate(user)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.seq++
+	record := &auth.UserRecord{
+		UserInfo: &auth.UserInfo{
+			UID:         fmt.Sprintf("uid-%d", f.seq),
+			PhoneNumber: phoneNumber,
+		},
+	}
+	f.users[phoneNumber] = record
+
+	return record, nil
+}
+
+// phoneNumberFromUserToCreate recovers the phone number passed to
+// (*auth.UserToCreate).PhoneNumber. UserToCreate only exposes its fields
+// through builder methods and has no getter or JSON marshaling of its own,
+// so there is no public way to read one back; we reach into its unexported
+// params field via reflection instead. If a future SDK version renames or
+// retypes that field, this returns "" rather than panicking.
+//
+// Verified against firebase.google.com/go v3.13.0+incompatible, where
+// UserToCreate.params is a map[string]interface{} keyed by wire field name
+// (see auth/user_mgt.go). A firebase-admin-go upgrade that changes that
+// layout will make this return "" silently; TestPhoneNumberFromUserToCreate
+// is the canary that should catch it first.
+func phoneNumberFromUserToCreate(user *auth.UserToCreate) string {
+	field := reflect.ValueOf(user).Elem().FieldByName("params")
+	if !field.IsValid() {
+		return ""
+	}
+	params, _ := reflect.NewAt(field.Type(), unsafe.Pointer(field.UnsafeAddr())).Elem().Interface().(map[string]interface{})
+	phone, _ := params["phoneNumber"].(string)
+	return phone
+}
+
+func (f *FakeFirebaseAuth) CustomToken(ctx context.Context, uid string) (string, error) {
+	if f.CustomTokenFunc != nil {
+		return f.CustomTokenFunc(ctx, uid)
+	}
+	return fmt.Sprintf("token-%s", uid), nil
+}
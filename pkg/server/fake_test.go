@@ -0,0 +1,23 @@
+package server
+
+import (
+	"testing"
+
+	"firebase.google.com/go/auth"
+)
+
+func TestPhoneNumberFromUserToCreate(t *testing.T) {
+	user := (&auth.UserToCreate{}).PhoneNumber(testPhoneNumber)
+
+	if got := phoneNumberFromUserToCreate(user); got != testPhoneNumber {
+		t.Fatalf("phoneNumberFromUserToCreate() = %q, want %q", got, testPhoneNumber)
+	}
+}
+
+func TestPhoneNumberFromUserToCreate_NoPhoneNumberSet(t *testing.T) {
+	user := &auth.UserToCreate{}
+
+	if got := phoneNumberFromUserToCreate(user); got != "" {
+		t.Fatalf("phoneNumberFromUserToCreate() = %q, want \"\"", got)
+	}
+}
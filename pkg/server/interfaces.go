@@ -0,0 +1,23 @@
+package server
+
+import (
+	"context"
+
+	"firebase.google.com/go/auth"
+	"github.com/google/uuid"
+)
+
+// DingVerifier abstracts the subset of *ding.Client the server needs, so
+// tests can substitute a fake instead of hitting the real Ding API.
+type DingVerifier interface {
+	Authenticate(ctx context.Context, phoneNumber string) (string, error)
+	Check(ctx context.Context, authUUID uuid.UUID, phoneNumber string, code string) (bool, error)
+}
+
+// FirebaseAuth abstracts the subset of *auth.Client the server needs, so
+// tests can substitute a fake instead of a live Firebase project.
+type FirebaseAuth interface {
+	GetUserByPhoneNumber(ctx context.Context, phoneNumber string) (*auth.UserRecord, error)
+	CreateUser(ctx context.Context, user *auth.UserToCreate) (*auth.UserRecord, error)
+	CustomToken(ctx context.Context, uid string) (string, error)
+}
@@ -0,0 +1,399 @@
+// Package server implements the HTTP surface of the Ding + Firebase phone
+// verification flow as a reusable, testable component: Config wires in the
+// Ding and Firebase dependencies (or fakes, in tests) and New returns a
+// Server whose Routes() is an http.Handler ready to mount or serve
+// directly. cmd/ is a thin composition root over this package.
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"firebase.google.com/go/auth"
+	"github.com/google/uuid"
+)
+
+const (
+	sessionCookieName = "ding_session"
+	sessionTTL        = 24 * time.Hour
+	csrfHeader        = "X-CSRF-Token"
+
+	// sweepInterval is how often StartSweeper removes expired pending auths
+	// and sessions.
+	sweepInterval = 5 * time.Minute
+)
+
+// Config configures a Server.
+type Config struct {
+	// DingVerifier sends and checks verification codes. Typically a
+	// *ding.Client.
+	DingVerifier DingVerifier
+	// FirebaseAuth looks up, creates, and mints tokens for users.
+	// Typically a *auth.Client.
+	FirebaseAuth FirebaseAuth
+	// SessionStore persists sessions created after a successful
+	// verification. Defaults to an InMemorySessionStore.
+	SessionStore SessionStore
+}
+
+// Server is the send/verify/session HTTP surface of the sample app.
+type Server struct {
+	verifier     DingVerifier
+	firebaseAuth FirebaseAuth
+	sessionStore SessionStore
+	pendingAuth  *pendingAuthStore
+}
+
+// New builds a Server from cfg.
+func New(cfg Config) *Server {
+	sessionStore := cfg.SessionStore
+	if sessionStore == nil {
+		sessionStore = NewInMemorySessionStore()
+	}
+
+	return &Server{
+		verifier:     cfg.DingVerifier,
+		firebaseAuth: cfg.FirebaseAuth,
+		sessionStore: sessionStore,
+		pendingAuth:  newPendingAuthStore(),
+	}
+}
+
+// sweepableSessionStore is implemented by SessionStore implementations that
+// keep expired sessions in memory until removed; BoltSessionStore relies on
+// bbolt's on-disk storage instead and doesn't need sweeping.
+type sweepableSessionStore interface {
+	Sweep(now time.Time) int
+}
+
+// StartSweeper runs a periodic background sweep of expired pending auths
+// and, if the configured SessionStore supports it, expired sessions, until
+// ctx is canceled. It is opt-in: pendingAuthTTL/sessionTTL already bound how
+// long a stale entry can affect a lookup, so short-lived processes (tests,
+// one-shot CLIs) can skip calling this; long-running processes should call
+// it once after New to bound the stores' memory use over time.
+func (s *Server) StartSweeper(ctx context.Context) {
+	sweepable, _ := s.sessionStore.(sweepableSessionStore)
+
+	go func() {
+		ticker := time.NewTicker(sweepInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				s.pendingAuth.sweep(now)
+				if sweepable != nil {
+					sweepable.Sweep(now)
+				}
+			}
+		}
+	}()
+}
+
+// Routes returns the Server's HTTP handler.
+func (s *Server) Routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/send_code", s.sendCode)
+	mux.HandleFunc("/verify", s.verify)
+	mux.HandleFunc("/session", s.session)
+	mux.HandleFunc("/refresh", s.refresh)
+	mux.HandleFunc("/logout", s.logout)
+	return mux
+}
+
+func (s *Server) sendCode(w http.ResponseWriter, r *http.Request) {
+	type request struct {
+		PhoneNumber string `json:"phone_number"`
+	}
+
+	type response struct {
+		AuthenticationUUID string `json:"authentication_uuid"`
+		State              string `json:"state"`
+	}
+
+	var req request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	authUUIDStr, err := s.verifier.Authenticate(r.Context(), req.PhoneNumber)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("%s", err), dingErrToHTTP(err))
+		return
+	}
+
+	authUUID, err := uuid.Parse(authUUIDStr)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid auth UUID: %s", err), http.StatusInternalServerError)
+		return
+	}
+
+	state, err := randomToken(16)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("generate state: %s", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Bind the state nonce to this phone number and authentication UUID so
+	// /verify can reject a code redeemed against a different account.
+	s.pendingAuth.put(pendingAuth{
+		State:              state,
+		PhoneNumber:        req.PhoneNumber,
+		AuthenticationUUID: authUUID,
+		ExpiresAt:          time.Now().Add(pendingAuthTTL),
+	})
+
+	writeJSON(w, http.StatusOK, response{AuthenticationUUID: authUUID.String(), State: state})
+}
+
+func (s *Server) verify(w http.ResponseWriter, r *http.Request) {
+	type request struct {
+		PhoneNumber        string `json:"phone_number"`
+		Code               string `json:"code"`
+		AuthenticationUUID string `json:"authentication_uuid"`
+		State              string `json:"state"`
+	}
+
+	type response struct {
+		CustomToken string `json:"custom_token"`
+		CSRFToken   string `json:"csrf_token"`
+	}
+
+	var req request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	authUUID, err := uuid.Parse(req.AuthenticationUUID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid auth UUID: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	pending, err := s.pendingAuth.peek(req.State)
+	if err != nil || pending.PhoneNumber != req.PhoneNumber || pending.AuthenticationUUID != authUUID {
+		http.Error(w, "invalid or expired state", http.StatusBadRequest)
+		return
+	}
+
+	success, err := s.verifier.Check(r.Context(), authUUID, req.PhoneNumber, req.Code)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("%s", err), dingErrToHTTP(err))
+		return
+	}
+
+	if !success {
+		http.Error(w, "invalid code", http.StatusUnauthorized)
+		return
+	}
+
+	// Only spend the state nonce once the code actually checks out, so a
+	// mistyped code can be retried against the same /send_code response
+	// instead of forcing the user to restart the flow. An abandoned nonce
+	// is still bounded by pendingAuthTTL.
+	s.pendingAuth.consume(req.State)
+
+	user, err := s.firebaseAuth.GetUserByPhoneNumber(r.Context(), req.PhoneNumber)
+	if err != nil {
+		newUser := (&auth.UserToCreate{}).PhoneNumber(req.PhoneNumber)
+		createdUser, err := s.firebaseAuth.CreateUser(r.Context(), newUser)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("create user: %s", err), http.StatusInternalServerError)
+			return
+		}
+		user = createdUser
+	}
+
+	session, err := s.createSession(r.Context(), user.UID, req.PhoneNumber)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("create session: %s", err), http.StatusInternalServerError)
+		return
+	}
+	setSessionCookie(w, session)
+
+	customToken, err := s.firebaseAuth.CustomToken(r.Context(), user.UID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("create custom token: %s", err), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, response{CustomToken: customToken, CSRFToken: session.CSRFToken})
+}
+
+// session exchanges the session cookie for a fresh Firebase custom token,
+// without rotating the session. Unlike refresh and logout it does not
+// change any state, so it does not require the CSRF header.
+func (s *Server) session(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		CustomToken string `json:"custom_token"`
+	}
+
+	session, err := s.currentSession(r)
+	if err != nil {
+		http.Error(w, "no active session", http.StatusUnauthorized)
+		return
+	}
+
+	customToken, err := s.firebaseAuth.CustomToken(r.Context(), session.UID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("create custom token: %s", err), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, response{CustomToken: customToken})
+}
+
+// refresh rotates the session (new ID, new CSRF token) and returns a fresh
+// Firebase custom token.
+func (s *Server) refresh(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		CustomToken string `json:"custom_token"`
+		CSRFToken   string `json:"csrf_token"`
+	}
+
+	session, err := s.currentSession(r)
+	if err != nil {
+		http.Error(w, "no active session", http.StatusUnauthorized)
+		return
+	}
+
+	if !validCSRF(r, session) {
+		http.Error(w, "invalid csrf token", http.StatusForbidden)
+		return
+	}
+
+	if err := s.sessionStore.Delete(r.Context(), session.ID); err != nil {
+		http.Error(w, fmt.Sprintf("delete session: %s", err), http.StatusInternalServerError)
+		return
+	}
+
+	newSession, err := s.createSession(r.Context(), session.UID, session.PhoneNumber)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("create session: %s", err), http.StatusInternalServerError)
+		return
+	}
+	setSessionCookie(w, newSession)
+
+	customToken, err := s.firebaseAuth.CustomToken(r.Context(), newSession.UID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("create custom token: %s", err), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, response{CustomToken: customToken, CSRFToken: newSession.CSRFToken})
+}
+
+// logout deletes the session and clears the cookie.
+func (s *Server) logout(w http.ResponseWriter, r *http.Request) {
+	session, err := s.currentSession(r)
+	if err != nil {
+		clearSessionCookie(w)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if !validCSRF(r, session) {
+		http.Error(w, "invalid csrf token", http.StatusForbidden)
+		return
+	}
+
+	if err := s.sessionStore.Delete(r.Context(), session.ID); err != nil {
+		http.Error(w, fmt.Sprintf("delete session: %s", err), http.StatusInternalServerError)
+		return
+	}
+
+	clearSessionCookie(w)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) createSession(ctx context.Context, uid, phoneNumber string) (*Session, error) {
+	id, err := newSessionID()
+	if err != nil {
+		return nil, fmt.Errorf("generate session id: %v", err)
+	}
+
+	csrfToken, err := newCSRFToken()
+	if err != nil {
+		return nil, fmt.Errorf("generate csrf token: %v", err)
+	}
+
+	now := time.Now()
+	session := &Session{
+		ID:          id,
+		UID:         uid,
+		PhoneNumber: phoneNumber,
+		IssuedAt:    now,
+		ExpiresAt:   now.Add(sessionTTL),
+		CSRFToken:   csrfToken,
+	}
+
+	if err := s.sessionStore.Create(ctx, session); err != nil {
+		return nil, fmt.Errorf("store session: %v", err)
+	}
+
+	return session, nil
+}
+
+// currentSession resolves the session referenced by the request's session
+// cookie, evicting it if expired.
+func (s *Server) currentSession(r *http.Request) (*Session, error) {
+	cookie, err := r.Cookie(sessionCookieName)
+	if err != nil {
+		return nil, ErrSessionNotFound
+	}
+
+	session, err := s.sessionStore.Get(r.Context(), cookie.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	if time.Now().After(session.ExpiresAt) {
+		_ = s.sessionStore.Delete(r.Context(), session.ID)
+		return nil, ErrSessionNotFound
+	}
+
+	return session, nil
+}
+
+func validCSRF(r *http.Request, session *Session) bool {
+	return subtle.ConstantTimeCompare([]byte(r.Header.Get(csrfHeader)), []byte(session.CSRFToken)) == 1
+}
+
+func setSessionCookie(w http.ResponseWriter, session *Session) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    session.ID,
+		Path:     "/",
+		Expires:  session.ExpiresAt,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+func clearSessionCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
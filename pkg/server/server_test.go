@@ -0,0 +1,393 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"firebase.google.com/go/auth"
+	"github.com/ding-live/firebase/pkg/ding"
+	"github.com/google/uuid"
+)
+
+const testPhoneNumber = "+15555550100"
+
+func newTestServer(verifier *FakeDingVerifier, firebaseAuth *FakeFirebaseAuth) *Server {
+	return New(Config{DingVerifier: verifier, FirebaseAuth: firebaseAuth})
+}
+
+func sendCode(t *testing.T, srv *Server, phoneNumber string) (authUUID, state string) {
+	t.Helper()
+
+	body, _ := json.Marshal(map[string]string{"phone_number": phoneNumber})
+	req := httptest.NewRequest(http.MethodPost, "/send_code", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	srv.Routes().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("send_code: status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+
+	var resp struct {
+		AuthenticationUUID string `json:"authentication_uuid"`
+		State              string `json:"state"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("send_code: decode response: %v", err)
+	}
+
+	return resp.AuthenticationUUID, resp.State
+}
+
+func TestSendVerifyFlow(t *testing.T) {
+	verifier := &FakeDingVerifier{ValidCode: "123456"}
+	firebaseAuth := NewFakeFirebaseAuth()
+	srv := newTestServer(verifier, firebaseAuth)
+
+	authUUID, state := sendCode(t, srv, testPhoneNumber)
+
+	body, _ := json.Marshal(map[string]string{
+		"phone_number":        testPhoneNumber,
+		"code":                "123456",
+		"authentication_uuid": authUUID,
+		"state":               state,
+	})
+	req := httptest.NewRequest(http.MethodPost, "/verify", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	srv.Routes().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("verify: status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+
+	var resp struct {
+		CustomToken string `json:"custom_token"`
+		CSRFToken   string `json:"csrf_token"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("verify: decode response: %v", err)
+	}
+	if resp.CustomToken == "" {
+		t.Fatal("verify: expected a non-empty custom token")
+	}
+	if resp.CSRFToken == "" {
+		t.Fatal("verify: expected a non-empty csrf token")
+	}
+
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != sessionCookieName {
+		t.Fatalf("verify: expected a %q cookie, got %v", sessionCookieName, cookies)
+	}
+
+	// The user didn't exist before verification, so it should have been
+	// created as a fallback.
+	if _, err := firebaseAuth.GetUserByPhoneNumber(context.Background(), testPhoneNumber); err != nil {
+		t.Fatalf("expected user to have been created, got: %v", err)
+	}
+}
+
+func TestVerify_ExistingUserIsReused(t *testing.T) {
+	verifier := &FakeDingVerifier{ValidCode: "123456"}
+	firebaseAuth := NewFakeFirebaseAuth()
+	srv := newTestServer(verifier, firebaseAuth)
+
+	existing, err := firebaseAuth.CreateUser(context.Background(), (&auth.UserToCreate{}).PhoneNumber(testPhoneNumber))
+	if err != nil {
+		t.Fatalf("seed existing user: %v", err)
+	}
+
+	var tokenUID string
+	firebaseAuth.CustomTokenFunc = func(ctx context.Context, uid string) (string, error) {
+		tokenUID = uid
+		return "token-" + uid, nil
+	}
+
+	authUUID, state := sendCode(t, srv, testPhoneNumber)
+
+	body, _ := json.Marshal(map[string]string{
+		"phone_number":        testPhoneNumber,
+		"code":                "123456",
+		"authentication_uuid": authUUID,
+		"state":               state,
+	})
+	req := httptest.NewRequest(http.MethodPost, "/verify", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	srv.Routes().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("verify: status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	if tokenUID != existing.UID {
+		t.Fatalf("custom token minted for uid %q, want the existing user's uid %q", tokenUID, existing.UID)
+	}
+}
+
+func TestVerify_WrongCode(t *testing.T) {
+	verifier := &FakeDingVerifier{ValidCode: "123456"}
+	srv := newTestServer(verifier, NewFakeFirebaseAuth())
+
+	authUUID, state := sendCode(t, srv, testPhoneNumber)
+
+	body, _ := json.Marshal(map[string]string{
+		"phone_number":        testPhoneNumber,
+		"code":                "000000",
+		"authentication_uuid": authUUID,
+		"state":               state,
+	})
+	req := httptest.NewRequest(http.MethodPost, "/verify", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	srv.Routes().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("verify with wrong code: status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestVerify_WrongCodeThenRetrySucceeds(t *testing.T) {
+	verifier := &FakeDingVerifier{ValidCode: "123456"}
+	srv := newTestServer(verifier, NewFakeFirebaseAuth())
+
+	authUUID, state := sendCode(t, srv, testPhoneNumber)
+
+	wrongBody, _ := json.Marshal(map[string]string{
+		"phone_number":        testPhoneNumber,
+		"code":                "000000",
+		"authentication_uuid": authUUID,
+		"state":               state,
+	})
+	wrongReq := httptest.NewRequest(http.MethodPost, "/verify", bytes.NewReader(wrongBody))
+	wrongRec := httptest.NewRecorder()
+	srv.Routes().ServeHTTP(wrongRec, wrongReq)
+
+	if wrongRec.Code != http.StatusUnauthorized {
+		t.Fatalf("verify with wrong code: status = %d, want %d", wrongRec.Code, http.StatusUnauthorized)
+	}
+
+	// A mistyped code must not burn the state nonce: the same state should
+	// still redeem with the correct code.
+	rightBody, _ := json.Marshal(map[string]string{
+		"phone_number":        testPhoneNumber,
+		"code":                "123456",
+		"authentication_uuid": authUUID,
+		"state":               state,
+	})
+	rightReq := httptest.NewRequest(http.MethodPost, "/verify", bytes.NewReader(rightBody))
+	rightRec := httptest.NewRecorder()
+	srv.Routes().ServeHTTP(rightRec, rightReq)
+
+	if rightRec.Code != http.StatusOK {
+		t.Fatalf("verify with corrected code: status = %d, body = %s", rightRec.Code, rightRec.Body.String())
+	}
+}
+
+func TestVerify_MalformedJSON(t *testing.T) {
+	srv := newTestServer(&FakeDingVerifier{}, NewFakeFirebaseAuth())
+
+	req := httptest.NewRequest(http.MethodPost, "/verify", bytes.NewReader([]byte("{not json")))
+	rec := httptest.NewRecorder()
+
+	srv.Routes().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("verify with malformed JSON: status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestVerify_BadUUID(t *testing.T) {
+	srv := newTestServer(&FakeDingVerifier{}, NewFakeFirebaseAuth())
+
+	body, _ := json.Marshal(map[string]string{
+		"phone_number":        testPhoneNumber,
+		"code":                "123456",
+		"authentication_uuid": "not-a-uuid",
+		"state":               "irrelevant",
+	})
+	req := httptest.NewRequest(http.MethodPost, "/verify", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	srv.Routes().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("verify with bad UUID: status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestVerify_UnknownState(t *testing.T) {
+	verifier := &FakeDingVerifier{ValidCode: "123456"}
+	srv := newTestServer(verifier, NewFakeFirebaseAuth())
+
+	body, _ := json.Marshal(map[string]string{
+		"phone_number":        testPhoneNumber,
+		"code":                "123456",
+		"authentication_uuid": uuid.NewString(),
+		"state":               "never-issued",
+	})
+	req := httptest.NewRequest(http.MethodPost, "/verify", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	srv.Routes().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("verify with unknown state: status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestSendCode_ErrorMapping(t *testing.T) {
+	cases := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{"unauthorized", ding.ErrUnauthorized, http.StatusUnauthorized},
+		{"invalid input", ding.ErrInvalidInput, http.StatusBadRequest},
+		{"rate limited", ding.ErrRateLimited, http.StatusTooManyRequests},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			verifier := &FakeDingVerifier{
+				AuthenticateFunc: func(ctx context.Context, phoneNumber string) (string, error) {
+					return "", tc.err
+				},
+			}
+			srv := newTestServer(verifier, NewFakeFirebaseAuth())
+
+			body, _ := json.Marshal(map[string]string{"phone_number": testPhoneNumber})
+			req := httptest.NewRequest(http.MethodPost, "/send_code", bytes.NewReader(body))
+			rec := httptest.NewRecorder()
+
+			srv.Routes().ServeHTTP(rec, req)
+
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.wantStatus)
+			}
+		})
+	}
+}
+
+func TestSession_RequiresCookie(t *testing.T) {
+	srv := newTestServer(&FakeDingVerifier{}, NewFakeFirebaseAuth())
+
+	req := httptest.NewRequest(http.MethodGet, "/session", nil)
+	rec := httptest.NewRecorder()
+
+	srv.Routes().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("session without cookie: status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestRefresh_RequiresCSRFToken(t *testing.T) {
+	verifier := &FakeDingVerifier{ValidCode: "123456"}
+	srv := newTestServer(verifier, NewFakeFirebaseAuth())
+
+	authUUID, state := sendCode(t, srv, testPhoneNumber)
+
+	verifyBody, _ := json.Marshal(map[string]string{
+		"phone_number":        testPhoneNumber,
+		"code":                "123456",
+		"authentication_uuid": authUUID,
+		"state":               state,
+	})
+	verifyReq := httptest.NewRequest(http.MethodPost, "/verify", bytes.NewReader(verifyBody))
+	verifyRec := httptest.NewRecorder()
+	srv.Routes().ServeHTTP(verifyRec, verifyReq)
+
+	cookie := verifyRec.Result().Cookies()[0]
+
+	// Missing CSRF header.
+	refreshReq := httptest.NewRequest(http.MethodPost, "/refresh", nil)
+	refreshReq.AddCookie(cookie)
+	refreshRec := httptest.NewRecorder()
+	srv.Routes().ServeHTTP(refreshRec, refreshReq)
+
+	if refreshRec.Code != http.StatusForbidden {
+		t.Fatalf("refresh without csrf header: status = %d, want %d", refreshRec.Code, http.StatusForbidden)
+	}
+}
+
+func TestPendingAuthStore_SweepRemovesExpired(t *testing.T) {
+	store := newPendingAuthStore()
+	now := time.Now()
+
+	store.put(pendingAuth{State: "expired", ExpiresAt: now.Add(-time.Second)})
+	store.put(pendingAuth{State: "live", ExpiresAt: now.Add(time.Minute)})
+
+	if removed := store.sweep(now); removed != 1 {
+		t.Fatalf("sweep removed %d entries, want 1", removed)
+	}
+
+	if _, err := store.peek("expired"); err != ErrStateNotFound {
+		t.Fatalf("peek(expired) err = %v, want ErrStateNotFound", err)
+	}
+	if _, err := store.peek("live"); err != nil {
+		t.Fatalf("peek(live) err = %v, want nil", err)
+	}
+}
+
+func TestInMemorySessionStore_SweepRemovesExpired(t *testing.T) {
+	store := NewInMemorySessionStore()
+	ctx := context.Background()
+	now := time.Now()
+
+	_ = store.Create(ctx, &Session{ID: "expired", ExpiresAt: now.Add(-time.Second)})
+	_ = store.Create(ctx, &Session{ID: "live", ExpiresAt: now.Add(time.Minute)})
+
+	if removed := store.Sweep(now); removed != 1 {
+		t.Fatalf("Sweep removed %d sessions, want 1", removed)
+	}
+
+	if _, err := store.Get(ctx, "expired"); err != ErrSessionNotFound {
+		t.Fatalf("Get(expired) err = %v, want ErrSessionNotFound", err)
+	}
+	if _, err := store.Get(ctx, "live"); err != nil {
+		t.Fatalf("Get(live) err = %v, want nil", err)
+	}
+}
+
+func TestLogout_ClearsCookie(t *testing.T) {
+	verifier := &FakeDingVerifier{ValidCode: "123456"}
+	srv := newTestServer(verifier, NewFakeFirebaseAuth())
+
+	authUUID, state := sendCode(t, srv, testPhoneNumber)
+
+	verifyBody, _ := json.Marshal(map[string]string{
+		"phone_number":        testPhoneNumber,
+		"code":                "123456",
+		"authentication_uuid": authUUID,
+		"state":               state,
+	})
+	verifyReq := httptest.NewRequest(http.MethodPost, "/verify", bytes.NewReader(verifyBody))
+	verifyRec := httptest.NewRecorder()
+	srv.Routes().ServeHTTP(verifyRec, verifyReq)
+
+	var verifyResp struct {
+		CSRFToken string `json:"csrf_token"`
+	}
+	_ = json.Unmarshal(verifyRec.Body.Bytes(), &verifyResp)
+	cookie := verifyRec.Result().Cookies()[0]
+
+	logoutReq := httptest.NewRequest(http.MethodPost, "/logout", nil)
+	logoutReq.AddCookie(cookie)
+	logoutReq.Header.Set(csrfHeader, verifyResp.CSRFToken)
+	logoutRec := httptest.NewRecorder()
+	srv.Routes().ServeHTTP(logoutRec, logoutReq)
+
+	if logoutRec.Code != http.StatusNoContent {
+		t.Fatalf("logout: status = %d, want %d", logoutRec.Code, http.StatusNoContent)
+	}
+
+	logoutCookies := logoutRec.Result().Cookies()
+	if len(logoutCookies) != 1 || logoutCookies[0].MaxAge >= 0 {
+		t.Fatalf("logout: expected cookie to be cleared, got %v", logoutCookies)
+	}
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrSessionNotFound is returned by SessionStore implementations when a
+// session ID has no matching record, either because it never existed or
+// because it already expired/was deleted.
+var ErrSessionNotFound = errors.New("session not found")
+
+// Session is a signed-cookie-backed session created after a successful
+// phone verification. It carries its own CSRF token so that state-changing
+// endpoints (Refresh, Logout) can require it alongside the cookie.
+type Session struct {
+	ID          string
+	UID         string
+	PhoneNumber string
+	IssuedAt    time.Time
+	ExpiresAt   time.Time
+	CSRFToken   string
+}
+
+// SessionStore persists sessions so that /session and /refresh can reissue
+// Firebase custom tokens without requiring the user to re-verify their
+// phone number on every request.
+type SessionStore interface {
+	Create(ctx context.Context, session *Session) error
+	Get(ctx context.Context, id string) (*Session, error)
+	Delete(ctx context.Context, id string) error
+}
+
+func newSessionID() (string, error) { return randomToken(32) }
+func newCSRFToken() (string, error) { return randomToken(32) }
+
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// InMemorySessionStore is a process-local SessionStore. It is the default
+// for single-instance deployments; BoltSessionStore is available for
+// sessions that should survive a process restart.
+type InMemorySessionStore struct {
+	mu       sync.RWMutex
+	sessions map[string]*Session
+}
+
+func NewInMemorySessionStore() *InMemorySessionStore {
+	return &InMemorySessionStore{sessions: make(map[string]*Session)}
+}
+
+func (s *InMemorySessionStore) Create(ctx context.Context, session *Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[session.ID] = session
+	return nil
+}
+
+func (s *InMemorySessionStore) Get(ctx context.Context, id string) (*Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	session, ok := s.sessions[id]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	return session, nil
+}
+
+func (s *InMemorySessionStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, id)
+	return nil
+}
+
+// Sweep removes sessions that have expired as of now, returning how many
+// were removed. It lets a long-running process bound memory use even when
+// an abandoned session is never looked up again; see Server.StartSweeper.
+func (s *InMemorySessionStore) Sweep(now time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := 0
+	for id, session := range s.sessions {
+		if now.After(session.ExpiresAt) {
+			delete(s.sessions, id)
+			removed++
+		}
+	}
+	return removed
+}
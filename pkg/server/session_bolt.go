@@ -0,0 +1,72 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+
+	"go.etcd.io/bbolt"
+)
+
+var sessionsBucket = []byte("sessions")
+
+// BoltSessionStore is a SessionStore backed by a local BoltDB file, for
+// deployments that need sessions to survive a process restart without
+// standing up an external store.
+type BoltSessionStore struct {
+	db *bbolt.DB
+}
+
+func NewBoltSessionStore(path string) (*BoltSessionStore, error) {
+	db, err := bbolt.Open(path, 0600, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(sessionsBucket)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &BoltSessionStore{db: db}, nil
+}
+
+func (s *BoltSessionStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *BoltSessionStore) Create(ctx context.Context, session *Session) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(sessionsBucket).Put([]byte(session.ID), data)
+	})
+}
+
+func (s *BoltSessionStore) Get(ctx context.Context, id string) (*Session, error) {
+	var session Session
+
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		data := tx.Bucket(sessionsBucket).Get([]byte(id))
+		if data == nil {
+			return ErrSessionNotFound
+		}
+		return json.Unmarshal(data, &session)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+func (s *BoltSessionStore) Delete(ctx context.Context, id string) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(sessionsBucket).Delete([]byte(id))
+	})
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrStateNotFound is returned when a state nonce is unknown, already
+// redeemed, or expired.
+var ErrStateNotFound = errors.New("state not found")
+
+// pendingAuthTTL bounds how long a /send_code state nonce remains
+// redeemable by /verify.
+const pendingAuthTTL = 10 * time.Minute
+
+// pendingAuth binds a /send_code response to the /verify call that must
+// follow it, so a code sent for one phone number cannot be redeemed against
+// a different account by an attacker who captures the authentication UUID.
+type pendingAuth struct {
+	State              string
+	PhoneNumber        string
+	AuthenticationUUID uuid.UUID
+	ExpiresAt          time.Time
+}
+
+// pendingAuthStore is a process-local, single-use store of pendingAuth
+// nonces.
+type pendingAuthStore struct {
+	mu      sync.Mutex
+	byState map[string]pendingAuth
+}
+
+func newPendingAuthStore() *pendingAuthStore {
+	return &pendingAuthStore{byState: make(map[string]pendingAuth)}
+}
+
+func (s *pendingAuthStore) put(p pendingAuth) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.byState[p.State] = p
+}
+
+// peek returns the pendingAuth for state without consuming it, so a caller
+// can validate it and attempt a code check before the nonce is spent; see
+// consume. An expired entry is dropped and reported as not found.
+func (s *pendingAuthStore) peek(state string) (pendingAuth, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.byState[state]
+	if !ok {
+		return pendingAuth{}, ErrStateNotFound
+	}
+
+	if time.Now().After(p.ExpiresAt) {
+		delete(s.byState, state)
+		return pendingAuth{}, ErrStateNotFound
+	}
+
+	return p, nil
+}
+
+// consume removes the pendingAuth for state so it can no longer be
+// redeemed. Callers should only consume a nonce once the code check
+// succeeds, so a mistyped code doesn't force the user back through
+// /send_code for a new one.
+func (s *pendingAuthStore) consume(state string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.byState, state)
+}
+
+// sweep removes pending auths that have expired as of now, returning how
+// many were removed. It lets a long-running process bound the store's size
+// even when a /send_code is never followed by a /verify; see
+// Server.StartSweeper.
+func (s *pendingAuthStore) sweep(now time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := 0
+	for state, p := range s.byState {
+		if now.After(p.ExpiresAt) {
+			delete(s.byState, state)
+			removed++
+		}
+	}
+	return removed
+}